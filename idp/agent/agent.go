@@ -0,0 +1,28 @@
+// Copyright 2015 Canonical Ltd.
+
+// Package agent provides the "agent" identity provider, which
+// identifies users using the agent login mechanism.
+package agent
+
+import "github.com/CanonicalLtd/candid/idp"
+
+// Type is the identity provider type name used in configuration to
+// select this identity provider.
+const Type = "agent"
+
+// IdentityProvider is an identity provider that uses the agent login
+// mechanism.
+var IdentityProvider = idp.IdentityProvider{
+	Type: Type,
+}
+
+func init() {
+	idp.Register(Type, unmarshal)
+}
+
+// unmarshal implements idp.Factory. The agent identity provider takes
+// no configuration beyond its type, so unmarshal simply returns
+// IdentityProvider.
+func unmarshal(unmarshal func(interface{}) error) (idp.IdentityProvider, error) {
+	return IdentityProvider, nil
+}
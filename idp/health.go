@@ -0,0 +1,16 @@
+// Copyright 2015 Canonical Ltd.
+
+package idp
+
+// HealthChecker is implemented by identity provider configurations
+// that are able to report on the reachability of the service they
+// talk to. When the Config of a configured IdentityProvider
+// implements HealthChecker, the debug status handler calls
+// HealthCheck to include that provider in its health checks, so an
+// identity provider type registered with Register automatically
+// contributes a check without any further wiring.
+type HealthChecker interface {
+	// HealthCheck contacts the identity provider's backing service
+	// and returns an error if it is not reachable.
+	HealthCheck() error
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 Canonical Ltd.
+
+package keystone
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gopkg.in/errgo.v1"
+)
+
+// appCredAuthRequest is the body sent to keystoneTokensPath to
+// authenticate using a Keystone application credential, as used by
+// the keystone_appcred identity provider.
+type appCredAuthRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods               []string `json:"methods"`
+			ApplicationCredential struct {
+				ID     string `json:"id"`
+				Secret string `json:"secret"`
+			} `json:"application_credential"`
+		} `json:"identity"`
+	} `json:"auth"`
+}
+
+// AuthenticateAppCred authenticates against p's Keystone server using
+// the application credential identified by id and secret, returning
+// the resulting user token.
+func (p *Params) AuthenticateAppCred(id, secret string) (string, error) {
+	var req appCredAuthRequest
+	req.Auth.Identity.Methods = []string{"application_credential"}
+	req.Auth.Identity.ApplicationCredential.ID = id
+	req.Auth.Identity.ApplicationCredential.Secret = secret
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", errgo.Mask(err)
+	}
+	resp, err := http.Post(p.URL+keystoneTokensPath, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return "", errgo.Notef(err, "cannot authenticate application credential")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", errgo.Newf("cannot authenticate application credential: unexpected response status %q", resp.Status)
+	}
+	token := resp.Header.Get("X-Subject-Token")
+	if token == "" {
+		return "", errgo.Newf("cannot authenticate application credential: no token returned")
+	}
+	return token, nil
+}
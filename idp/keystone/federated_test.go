@@ -0,0 +1,69 @@
+// Copyright 2015 Canonical Ltd.
+
+package keystone
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFederatedTestServer starts an httptest.Server that serves a fake
+// Keystone v3 API sufficient to exercise ValidateFederatedToken. It
+// responds to keystoneTokensPath only when the request is
+// admin-authenticated with X-Auth-Token set to "fake-admin-token" and
+// presents federatedToken as the token to validate in X-Subject-Token;
+// any other combination, in particular an attempt to authenticate the
+// request with the federated token itself, fails the test.
+func newFederatedTestServer(t *testing.T, federatedToken, userID string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "POST":
+			w.Header().Set("X-Subject-Token", "fake-admin-token")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		case "GET":
+			if req.Header.Get("X-Subject-Token") != federatedToken {
+				t.Fatalf("X-Subject-Token was %q, want the federated token %q", req.Header.Get("X-Subject-Token"), federatedToken)
+			}
+			if req.Header.Get("X-Auth-Token") == federatedToken {
+				t.Fatal("request was authenticated with the federated token itself, not an admin token")
+			}
+			if req.Header.Get("X-Auth-Token") != "fake-admin-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"token":{"user":{"id":"` + userID + `","name":"` + userID + `"}}}`))
+		default:
+			http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFederatedAuthURL(t *testing.T) {
+	p := testParams("https://keystone.example.com")
+	p.IdentityProviderID = "my-idp"
+	p.FederationProtocol = "saml2"
+	got := p.FederatedAuthURL("https://candid.example.com/callback")
+	want := "https://keystone.example.com/v3/OS-FEDERATION/identity_providers/my-idp/protocols/saml2/websso?origin=https%3A%2F%2Fcandid.example.com%2Fcallback"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestValidateFederatedToken(t *testing.T) {
+	const federatedToken = "fake-federated-token"
+	srv := newFederatedTestServer(t, federatedToken, "user1")
+	defer srv.Close()
+	p := testParams(srv.URL)
+	userID, err := p.ValidateFederatedToken(federatedToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userID != "user1" {
+		t.Fatalf("unexpected user id: %q", userID)
+	}
+}
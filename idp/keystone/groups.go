@@ -0,0 +1,221 @@
+// Copyright 2015 Canonical Ltd.
+
+package keystone
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gopkg.in/errgo.v1"
+)
+
+// keystoneTokensPath is the path, relative to a Params.URL, used to
+// create and validate tokens.
+const keystoneTokensPath = "/v3/auth/tokens"
+
+// adminAuthRequest is the body sent to keystoneTokensPath to obtain an
+// admin-scoped token using the credentials configured in
+// Params.AdminUsername, Params.AdminPassword and Params.AdminDomain.
+type adminAuthRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password struct {
+				User struct {
+					Name     string `json:"name"`
+					Password string `json:"password"`
+					Domain   struct {
+						Name string `json:"name"`
+					} `json:"domain"`
+				} `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+	} `json:"auth"`
+}
+
+// userGroupsResponse is the body returned by Keystone's
+// /v3/users/{id}/groups endpoint.
+type userGroupsResponse struct {
+	Groups []struct {
+		Name string `json:"name"`
+	} `json:"groups"`
+}
+
+// adminToken obtains an admin-scoped token for p using the
+// administrative service account configured on p. It is only valid to
+// call adminToken when p.AdminUsername is set.
+func (p *Params) adminToken() (string, error) {
+	var req adminAuthRequest
+	req.Auth.Identity.Methods = []string{"password"}
+	req.Auth.Identity.Password.User.Name = p.AdminUsername
+	req.Auth.Identity.Password.User.Password = p.AdminPassword
+	req.Auth.Identity.Password.User.Domain.Name = p.AdminDomain
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", errgo.Mask(err)
+	}
+	resp, err := http.Post(p.URL+keystoneTokensPath, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return "", errgo.Notef(err, "cannot obtain admin token")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", errgo.Newf("cannot obtain admin token: unexpected response status %q", resp.Status)
+	}
+	token := resp.Header.Get("X-Subject-Token")
+	if token == "" {
+		return "", errgo.Newf("cannot obtain admin token: no token returned")
+	}
+	return token, nil
+}
+
+// IdentityProviderName implements idp.Namer by returning the
+// configured Name of p, so that multiple Keystone identity providers
+// of the same Type can be told apart, for example in /debug/status.
+func (p *Params) IdentityProviderName() string {
+	return p.Name
+}
+
+// HealthCheck implements idp.HealthChecker by checking that the
+// configured Keystone server is reachable.
+func (p *Params) HealthCheck() error {
+	resp, err := http.Get(p.URL + "/v3")
+	if err != nil {
+		return errgo.Notef(err, "cannot reach keystone server")
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errgo.Newf("keystone server returned unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+// userExists reports whether the user with the given id still exists
+// in Keystone, using adminToken to authenticate the request.
+func (p *Params) userExists(adminToken, userID string) (bool, error) {
+	req, err := http.NewRequest("GET", p.URL+"/v3/users/"+userID, nil)
+	if err != nil {
+		return false, errgo.Mask(err)
+	}
+	req.Header.Set("X-Auth-Token", adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, errgo.Notef(err, "cannot query user")
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errgo.Newf("cannot query user: unexpected response status %q", resp.Status)
+	}
+}
+
+// userGroups fetches the names of the groups that the user with the
+// given id belongs to, using adminToken to authenticate the request.
+// The group names are mapped into Candid groups, prefixed with
+// p.Domain when set.
+func (p *Params) userGroups(adminToken, userID string) ([]string, error) {
+	req, err := http.NewRequest("GET", p.URL+"/v3/users/"+userID+"/groups", nil)
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	req.Header.Set("X-Auth-Token", adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot query user groups")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errgo.Newf("cannot query user groups: unexpected response status %q", resp.Status)
+	}
+	var body userGroupsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errgo.Notef(err, "cannot unmarshal user groups")
+	}
+	groups := make([]string, len(body.Groups))
+	for i, g := range body.Groups {
+		groups[i] = p.qualify(g.Name)
+	}
+	return groups, nil
+}
+
+// qualify appends p.Domain to name, when set, in the same way
+// usernames are qualified.
+func (p *Params) qualify(name string) string {
+	if p.Domain == "" {
+		return name
+	}
+	return name + "@" + p.Domain
+}
+
+// GroupsScope is the login scope that a caller must request before
+// Groups will query Keystone for a user's group memberships. Requests
+// that do not ask for GroupsScope are not charged the cost of the
+// extra admin-token round trip.
+const GroupsScope = "groups"
+
+// requestsGroups reports whether scopes, the scopes requested by the
+// caller, includes GroupsScope.
+func requestsGroups(scopes []string) bool {
+	for _, s := range scopes {
+		if s == GroupsScope {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups fetches the group memberships of the user with the given id
+// from Keystone, using the administrative service account configured
+// on p. It only contacts Keystone when scopes, the scopes requested
+// by the caller, includes GroupsScope; otherwise, or when
+// p.AdminUsername is not set, it returns no groups without making any
+// admin round-trip.
+func (p *Params) Groups(userID string, scopes []string) ([]string, error) {
+	if !requestsGroups(scopes) {
+		return nil, nil
+	}
+	if p.AdminUsername == "" {
+		return nil, nil
+	}
+	token, err := p.adminToken()
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	groups, err := p.userGroups(token, userID)
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	return groups, nil
+}
+
+// Refresh re-queries Keystone for the current group memberships of
+// the user with the given id, first checking that the user still
+// exists. It is used to keep long-lived Candid macaroons in sync with
+// changes made in Keystone. Refresh returns an error if the user no
+// longer exists.
+func (p *Params) Refresh(userID string) ([]string, error) {
+	if p.AdminUsername == "" {
+		return nil, errgo.Newf("cannot refresh: no administrator credentials configured")
+	}
+	token, err := p.adminToken()
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	ok, err := p.userExists(token, userID)
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	if !ok {
+		return nil, errgo.Newf("user %q no longer exists", userID)
+	}
+	groups, err := p.userGroups(token, userID)
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	return groups, nil
+}
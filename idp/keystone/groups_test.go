@@ -0,0 +1,137 @@
+// Copyright 2015 Canonical Ltd.
+
+package keystone
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer starts an httptest.Server that serves a fake
+// Keystone v3 API sufficient to exercise adminToken, userGroups,
+// userExists and HealthCheck. userExists reports true only for
+// userID.
+func newTestServer(t *testing.T, userID string, groups []string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v3/auth/tokens", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Subject-Token", "fake-admin-token")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/v3/users/"+userID, func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Auth-Token") != "fake-admin-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v3/users/"+userID+"/groups", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Auth-Token") != "fake-admin-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		body := `{"groups":[`
+		for i, g := range groups {
+			if i > 0 {
+				body += ","
+			}
+			body += `{"name":"` + g + `"}`
+		}
+		body += `]}`
+		w.Write([]byte(body))
+	})
+	return httptest.NewServer(mux)
+}
+
+func testParams(url string) *Params {
+	return &Params{
+		Name:          "test",
+		URL:           url,
+		AdminUsername: "admin",
+		AdminPassword: "secret",
+		AdminDomain:   "admin_domain",
+	}
+}
+
+func TestAdminToken(t *testing.T) {
+	srv := newTestServer(t, "user1", nil)
+	defer srv.Close()
+	p := testParams(srv.URL)
+	token, err := p.adminToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "fake-admin-token" {
+		t.Fatalf("unexpected token: %q", token)
+	}
+}
+
+func TestUserGroups(t *testing.T) {
+	srv := newTestServer(t, "user1", []string{"group1", "group2"})
+	defer srv.Close()
+	p := testParams(srv.URL)
+	p.Domain = "example.com"
+	groups, err := p.Groups("user1", []string{GroupsScope})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"group1@example.com", "group2@example.com"}
+	if len(groups) != len(want) {
+		t.Fatalf("got %v, want %v", groups, want)
+	}
+	for i := range want {
+		if groups[i] != want[i] {
+			t.Fatalf("got %v, want %v", groups, want)
+		}
+	}
+}
+
+func TestGroupsNotRequested(t *testing.T) {
+	srv := newTestServer(t, "user1", []string{"group1"})
+	defer srv.Close()
+	p := testParams(srv.URL)
+	groups, err := p.Groups("user1", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if groups != nil {
+		t.Fatalf("expected no groups to be fetched, got %v", groups)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	srv := newTestServer(t, "user1", []string{"group1"})
+	defer srv.Close()
+	p := testParams(srv.URL)
+	groups, err := p.Refresh("user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 1 || groups[0] != "group1" {
+		t.Fatalf("unexpected groups: %v", groups)
+	}
+}
+
+func TestRefreshUserNotFound(t *testing.T) {
+	srv := newTestServer(t, "user1", nil)
+	defer srv.Close()
+	p := testParams(srv.URL)
+	_, err := p.Refresh("missing-user")
+	if err == nil {
+		t.Fatal("expected an error for a missing user")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	srv := newTestServer(t, "user1", nil)
+	defer srv.Close()
+	p := testParams(srv.URL)
+	if err := p.HealthCheck(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2015 Canonical Ltd.
+
+// Package keystone provides the "keystone", "keystone_userpass",
+// "keystone_token", "keystone_appcred" and "keystone_federated"
+// identity providers, which authenticate users against an OpenStack
+// Keystone service.
+package keystone
+
+import (
+	"gopkg.in/errgo.v1"
+
+	"github.com/CanonicalLtd/candid/idp"
+)
+
+// The type names used in configuration to select one of the identity
+// providers provided by this package.
+const (
+	Type          = "keystone"
+	UserpassType  = "keystone_userpass"
+	TokenType     = "keystone_token"
+	AppCredType   = "keystone_appcred"
+	FederatedType = "keystone_federated"
+)
+
+func init() {
+	idp.Register(Type, unmarshal(Type))
+	idp.Register(UserpassType, unmarshal(UserpassType))
+	idp.Register(TokenType, unmarshal(TokenType))
+	idp.Register(AppCredType, unmarshal(AppCredType))
+	idp.Register(FederatedType, unmarshal(FederatedType))
+}
+
+// Params holds the parameters to use with a Keystone identity
+// provider.
+type Params struct {
+	// Name is the name that the identity provider will have within
+	// the identity manager. The name is used as part of the url for
+	// communicating with the identity provider.
+	Name string `yaml:"name"`
+
+	// If Domain is set it will be appended to any usernames or
+	// groups provided by the identity provider. A user created by
+	// this identity provide would be username@domain.
+	Domain string `yaml:"domain"`
+
+	// Description is a human readable description that will be used
+	// if a list of providers is shown for a user to choose.
+	Description string `yaml:"description"`
+
+	// URL is the address of the keystone server.
+	URL string `yaml:"url"`
+
+	// AdminUsername, if set, is the username of a Keystone service
+	// account that is used to look up the groups a user belongs to
+	// after they have authenticated. AdminUsername, AdminPassword
+	// and AdminDomain must either all be set, or all be empty.
+	AdminUsername string `yaml:"admin-username"`
+
+	// AdminPassword is the password of the service account
+	// identified by AdminUsername.
+	AdminPassword string `yaml:"admin-password"`
+
+	// AdminDomain is the domain of the service account identified
+	// by AdminUsername.
+	AdminDomain string `yaml:"admin-domain"`
+
+	// FederationProtocol is the name of the federation protocol
+	// configured in Keystone for this identity provider, such as
+	// "saml2" or "oidc". It is only used by the keystone_federated
+	// identity provider.
+	FederationProtocol string `yaml:"federation-protocol"`
+
+	// IdentityProviderID is the id of the federated identity
+	// provider as registered in Keystone. It is only used by the
+	// keystone_federated identity provider.
+	IdentityProviderID string `yaml:"identity-provider-id"`
+}
+
+// NewIdentityProvider creates a new identity provider using a
+// keystone service.
+func NewIdentityProvider(p *Params) idp.IdentityProvider {
+	return newIdentityProvider(Type, p)
+}
+
+// NewUserpassIdentityProvider creates a new identity provider using a
+// keystone service with a non-interactive interface.
+func NewUserpassIdentityProvider(p *Params) idp.IdentityProvider {
+	return newIdentityProvider(UserpassType, p)
+}
+
+// NewTokenIdentityProvider creates a new identity provider that
+// identifies users using Keystone user tokens.
+func NewTokenIdentityProvider(p *Params) idp.IdentityProvider {
+	return newIdentityProvider(TokenType, p)
+}
+
+// NewAppCredIdentityProvider creates a new identity provider that
+// authenticates using a Keystone application credential.
+func NewAppCredIdentityProvider(p *Params) idp.IdentityProvider {
+	return newIdentityProvider(AppCredType, p)
+}
+
+// NewFederatedIdentityProvider creates a new identity provider that
+// accepts a pre-issued federated token from an upstream SAML/OIDC
+// identity provider registered with Keystone.
+func NewFederatedIdentityProvider(p *Params) idp.IdentityProvider {
+	return newIdentityProvider(FederatedType, p)
+}
+
+// newIdentityProvider creates a new identity provider using a
+// keystone service with the specified type.
+func newIdentityProvider(t string, p *Params) idp.IdentityProvider {
+	return idp.IdentityProvider{
+		Type:   t,
+		Config: p,
+	}
+}
+
+// unmarshal returns an idp.Factory that unmarshals the configuration
+// for the keystone identity provider variant named by t.
+func unmarshal(t string) idp.Factory {
+	return func(unmarshal func(interface{}) error) (idp.IdentityProvider, error) {
+		var p Params
+		if err := unmarshal(&p); err != nil {
+			return idp.IdentityProvider{}, errgo.Mask(err)
+		}
+		if p.Name == "" {
+			return idp.IdentityProvider{}, errgo.Newf("name not specified")
+		}
+		if p.URL == "" {
+			return idp.IdentityProvider{}, errgo.Newf("url not specified")
+		}
+		if (p.AdminUsername == "") != (p.AdminPassword == "") || (p.AdminUsername == "") != (p.AdminDomain == "") {
+			return idp.IdentityProvider{}, errgo.Newf("admin-username, admin-password and admin-domain must all be specified together")
+		}
+		if t == FederatedType {
+			if p.FederationProtocol == "" {
+				return idp.IdentityProvider{}, errgo.Newf("federation-protocol not specified")
+			}
+			if p.IdentityProviderID == "" {
+				return idp.IdentityProvider{}, errgo.Newf("identity-provider-id not specified")
+			}
+			if p.AdminUsername == "" {
+				return idp.IdentityProvider{}, errgo.Newf("admin-username, admin-password and admin-domain must be specified for %s", FederatedType)
+			}
+		}
+		return newIdentityProvider(t, &p), nil
+	}
+}
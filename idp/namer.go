@@ -0,0 +1,17 @@
+// Copyright 2015 Canonical Ltd.
+
+package idp
+
+// Namer is implemented by identity provider configurations that know
+// the name they were configured with. Configuration for an identity
+// provider type that only ever has a single, unnamed instance (such
+// as usso) need not implement it. When the Config of a configured
+// IdentityProvider implements Namer, places that otherwise key or
+// label results by Type alone, such as the debug status handler, use
+// IdentityProviderName to tell apart multiple configured instances of
+// the same Type.
+type Namer interface {
+	// IdentityProviderName returns the name the identity provider
+	// was configured with.
+	IdentityProviderName() string
+}
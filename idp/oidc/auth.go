@@ -0,0 +1,107 @@
+// Copyright 2015 Canonical Ltd.
+
+package oidc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/errgo.v1"
+)
+
+// AuthCodeURL builds the URL that the user should be redirected to in
+// order to begin the OIDC Authorization Code flow, along with the
+// PKCE code verifier generated for the request, which must be passed
+// back in to Exchange once the user has been redirected back to
+// redirectURL with an authorization code. state is returned unchanged
+// in the redirect and should be used by the caller to protect against
+// CSRF.
+func (p *Params) AuthCodeURL(state, redirectURL string) (string, *codeVerifier, error) {
+	cfg, err := p.discover()
+	if err != nil {
+		return "", nil, errgo.Mask(err)
+	}
+	v, err := newCodeVerifier()
+	if err != nil {
+		return "", nil, errgo.Mask(err)
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.Scopes...), " ")},
+		"state":                 {state},
+		"code_challenge":        {v.Challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return cfg.AuthorizationEndpoint + "?" + q.Encode(), v, nil
+}
+
+// tokenResponse is the body returned from a provider's token endpoint.
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+// Exchange completes the Authorization Code flow by exchanging code,
+// the authorization code returned to redirectURL, for an ID token,
+// using verifier to satisfy the PKCE challenge sent in the
+// corresponding call to AuthCodeURL. The ID token's signature and
+// standard claims are validated, and the username and group
+// memberships of the authenticated user, mapped according to p, are
+// returned.
+func (p *Params) Exchange(code, redirectURL string, verifier *codeVerifier) (username string, groups []string, err error) {
+	cfg, err := p.discover()
+	if err != nil {
+		return "", nil, errgo.Mask(err)
+	}
+	idToken, err := p.exchangeCode(cfg, code, redirectURL, verifier)
+	if err != nil {
+		return "", nil, errgo.Mask(err)
+	}
+	raw, err := p.verifyIDToken(cfg, idToken)
+	if err != nil {
+		return "", nil, errgo.Mask(err)
+	}
+	c := claims{
+		Subject:  stringClaim(raw, "sub"),
+		Email:    stringClaim(raw, "email"),
+		Username: stringClaim(raw, "preferred_username"),
+	}
+	return p.username(c), p.groups(raw), nil
+}
+
+// exchangeCode performs the token endpoint request that exchanges an
+// authorization code for an ID token.
+func (p *Params) exchangeCode(cfg *providerConfig, code, redirectURL string, verifier *codeVerifier) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier.Verifier},
+	}
+	resp, err := http.PostForm(cfg.TokenEndpoint, form)
+	if err != nil {
+		return "", errgo.Notef(err, "cannot exchange authorization code")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errgo.Newf("cannot exchange authorization code: unexpected response status %q", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errgo.Notef(err, "cannot read token response")
+	}
+	var tok tokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", errgo.Notef(err, "cannot unmarshal token response")
+	}
+	if tok.IDToken == "" {
+		return "", errgo.Newf("token response contained no ID token")
+	}
+	return tok.IDToken, nil
+}
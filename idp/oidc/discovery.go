@@ -0,0 +1,123 @@
+// Copyright 2015 Canonical Ltd.
+
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"gopkg.in/errgo.v1"
+)
+
+// wellKnownPath is appended to a Params.Issuer to locate the OIDC
+// discovery document.
+const wellKnownPath = "/.well-known/openid-configuration"
+
+// providerConfig holds the subset of the OIDC discovery document that
+// is needed to drive the authorization code flow and validate ID
+// tokens.
+type providerConfig struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discover fetches and decodes the OIDC discovery document for p.
+func (p *Params) discover() (*providerConfig, error) {
+	resp, err := http.Get(p.Issuer + wellKnownPath)
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot fetch OIDC discovery document")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errgo.Newf("cannot fetch OIDC discovery document: unexpected response status %q", resp.Status)
+	}
+	var cfg providerConfig
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, errgo.Notef(err, "cannot unmarshal OIDC discovery document")
+	}
+	return &cfg, nil
+}
+
+// codeVerifier is a PKCE code verifier, generated fresh for each
+// authorization request, together with its S256 code challenge.
+type codeVerifier struct {
+	Verifier  string
+	Challenge string
+}
+
+// newCodeVerifier generates a new PKCE code verifier and its
+// corresponding S256 code challenge, as described in RFC 7636.
+func newCodeVerifier() (*codeVerifier, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errgo.Notef(err, "cannot generate PKCE code verifier")
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	return &codeVerifier{
+		Verifier:  verifier,
+		Challenge: challenge,
+	}, nil
+}
+
+// claims holds the subset of standard OIDC claims that are mapped
+// into Candid users.
+type claims struct {
+	Subject  string `json:"sub"`
+	Email    string `json:"email"`
+	Username string `json:"preferred_username"`
+}
+
+// groups extracts the groups claim named by p.GroupsClaim from the
+// raw claim set, if configured, mapping it into Candid groups
+// prefixed by p.Domain when set.
+func (p *Params) groups(raw map[string]interface{}) []string {
+	if p.GroupsClaim == "" {
+		return nil
+	}
+	v, ok := raw[p.GroupsClaim]
+	if !ok {
+		return nil
+	}
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	groups := make([]string, 0, len(vs))
+	for _, g := range vs {
+		s, ok := g.(string)
+		if !ok {
+			continue
+		}
+		groups = append(groups, p.qualify(s))
+	}
+	return groups
+}
+
+// qualify appends p.Domain to name, when set, so that usernames and
+// groups from different issuers do not collide.
+func (p *Params) qualify(name string) string {
+	if p.Domain == "" {
+		return name
+	}
+	return name + "@" + p.Domain
+}
+
+// username derives the Candid username for a user from their claims,
+// preferring the preferred_username claim and falling back to email
+// and then the subject.
+func (p *Params) username(c claims) string {
+	name := c.Username
+	if name == "" {
+		name = c.Email
+	}
+	if name == "" {
+		name = c.Subject
+	}
+	return p.qualify(name)
+}
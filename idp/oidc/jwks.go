@@ -0,0 +1,68 @@
+// Copyright 2015 Canonical Ltd.
+
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+
+	"gopkg.in/errgo.v1"
+)
+
+// jwk is a single JSON Web Key, as returned from an OIDC provider's
+// jwks_uri. Only the fields needed to reconstruct an RSA public key
+// are decoded; other key types are not supported.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwkSet is the document returned from an OIDC provider's jwks_uri.
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// fetchJWKS fetches and decodes the JSON Web Key Set from uri.
+func fetchJWKS(uri string) (*jwkSet, error) {
+	resp, err := http.Get(uri)
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot fetch JWKS")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errgo.Newf("cannot fetch JWKS: unexpected response status %q", resp.Status)
+	}
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, errgo.Notef(err, "cannot unmarshal JWKS")
+	}
+	return &set, nil
+}
+
+// publicKey returns the RSA public key in the set with the given key
+// id, for use in validating an ID token's signature.
+func (s *jwkSet) publicKey(kid string) (*rsa.PublicKey, error) {
+	for _, k := range s.Keys {
+		if k.Kid != kid || k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, errgo.Notef(err, "cannot decode RSA modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, errgo.Notef(err, "cannot decode RSA exponent")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	}
+	return nil, errgo.Newf("no matching key found for kid %q", kid)
+}
@@ -0,0 +1,116 @@
+// Copyright 2015 Canonical Ltd.
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gopkg.in/errgo.v1"
+)
+
+// jwtHeader holds the subset of a JWT header needed to locate the key
+// used to sign it.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// verifyIDToken checks the signature of the compact-serialized JWT
+// idToken against the key identified by its header's kid in the key
+// set at cfg.JWKSURI, and checks that its iss and aud claims match p
+// and that it has not expired. It returns the token's decoded claim
+// set for further processing.
+func (p *Params) verifyIDToken(cfg *providerConfig, idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errgo.Newf("invalid ID token: expected 3 segments, got %d", len(parts))
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot decode ID token header")
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, errgo.Notef(err, "cannot unmarshal ID token header")
+	}
+	if header.Alg != "RS256" {
+		return nil, errgo.Newf("unsupported ID token signing algorithm %q", header.Alg)
+	}
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot decode ID token claims")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot decode ID token signature")
+	}
+	jwks, err := fetchJWKS(cfg.JWKSURI)
+	if err != nil {
+		return nil, errgo.Mask(err)
+	}
+	key, err := jwks.publicKey(header.Kid)
+	if err != nil {
+		return nil, errgo.Notef(err, "cannot validate ID token signature")
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig); err != nil {
+		return nil, errgo.Notef(err, "cannot validate ID token signature")
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(payloadJSON, &raw); err != nil {
+		return nil, errgo.Notef(err, "cannot unmarshal ID token claims")
+	}
+	if err := p.checkClaims(raw); err != nil {
+		return nil, errgo.Mask(err)
+	}
+	return raw, nil
+}
+
+// checkClaims checks that the standard iss, aud and exp claims in raw
+// are valid for p.
+func (p *Params) checkClaims(raw map[string]interface{}) error {
+	if iss, _ := raw["iss"].(string); iss != p.Issuer {
+		return errgo.Newf("ID token has unexpected issuer %q", iss)
+	}
+	if !audienceContains(raw["aud"], p.ClientID) {
+		return errgo.Newf("ID token is not intended for this client")
+	}
+	exp, ok := raw["exp"].(float64)
+	if !ok {
+		return errgo.Newf("ID token has no expiry claim")
+	}
+	if time.Unix(int64(exp), 0).Before(time.Now()) {
+		return errgo.Newf("ID token has expired")
+	}
+	return nil
+}
+
+// audienceContains reports whether the aud claim, which may be either
+// a single string or an array of strings as permitted by the OIDC
+// specification, contains clientID.
+func audienceContains(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringClaim returns the string value of the named claim in raw, or
+// the empty string if it is absent or not a string.
+func stringClaim(raw map[string]interface{}, name string) string {
+	s, _ := raw[name].(string)
+	return s
+}
@@ -0,0 +1,93 @@
+// Copyright 2015 Canonical Ltd.
+
+// Package oidc provides the "oidc" identity provider, which
+// authenticates users against an OpenID Connect / OAuth2 provider
+// such as Google, Okta, Keycloak or Dex using the Authorization Code
+// flow with PKCE.
+package oidc
+
+import (
+	"gopkg.in/errgo.v1"
+
+	"github.com/CanonicalLtd/candid/idp"
+)
+
+// Type is the identity provider type name used in configuration to
+// select this identity provider.
+const Type = "oidc"
+
+func init() {
+	idp.Register(Type, unmarshal)
+}
+
+// Params holds the parameters to use with an OIDC identity provider.
+type Params struct {
+	// Name is the name that the identity provider will have within
+	// the identity manager. The name is used as part of the url for
+	// communicating with the identity provider.
+	Name string `yaml:"name"`
+
+	// Issuer is the OIDC issuer URL. The provider's configuration,
+	// including its authorization, token and JWKS endpoints, is
+	// discovered from Issuer + "/.well-known/openid-configuration".
+	Issuer string `yaml:"issuer"`
+
+	// ClientID is the OAuth2 client id registered with the issuer
+	// for Candid.
+	ClientID string `yaml:"client-id"`
+
+	// ClientSecret is the OAuth2 client secret registered with the
+	// issuer for Candid.
+	ClientSecret string `yaml:"client-secret"`
+
+	// Scopes holds the OAuth2 scopes to request in addition to the
+	// required "openid" scope. A typical value would include
+	// "profile" and "email".
+	Scopes []string `yaml:"scopes"`
+
+	// GroupsClaim names the claim in the ID token that carries the
+	// groups a user belongs to. If not set no groups are derived
+	// from the ID token.
+	GroupsClaim string `yaml:"groups-claim"`
+
+	// If Domain is set it will be appended to any usernames or
+	// groups provided by the identity provider, in the same way as
+	// for the keystone identity providers. A user created by this
+	// identity provider would be username@domain.
+	Domain string `yaml:"domain"`
+}
+
+// NewIdentityProvider creates a new identity provider using an OIDC
+// issuer.
+func NewIdentityProvider(p *Params) idp.IdentityProvider {
+	return idp.IdentityProvider{
+		Type:   Type,
+		Config: p,
+	}
+}
+
+// IdentityProviderName implements idp.Namer by returning the
+// configured Name of p, so that multiple OIDC identity providers
+// (for example two distinct issuers) can be told apart, for example
+// in /debug/status.
+func (p *Params) IdentityProviderName() string {
+	return p.Name
+}
+
+// unmarshal implements idp.Factory.
+func unmarshal(unmarshal func(interface{}) error) (idp.IdentityProvider, error) {
+	var p Params
+	if err := unmarshal(&p); err != nil {
+		return idp.IdentityProvider{}, errgo.Mask(err)
+	}
+	if p.Name == "" {
+		return idp.IdentityProvider{}, errgo.Newf("name not specified")
+	}
+	if p.Issuer == "" {
+		return idp.IdentityProvider{}, errgo.Newf("issuer not specified")
+	}
+	if p.ClientID == "" {
+		return idp.IdentityProvider{}, errgo.Newf("client-id not specified")
+	}
+	return NewIdentityProvider(&p), nil
+}
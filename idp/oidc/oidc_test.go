@@ -0,0 +1,189 @@
+// Copyright 2015 Canonical Ltd.
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testIssuer is an httptest.Server together with the RSA key it signs
+// ID tokens with, sufficient to exercise discover, exchangeCode and
+// verifyIDToken.
+type testIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	// nextIDToken is the ID token returned by the token endpoint on
+	// the next call. Tests set it after starting the server, once
+	// they know its URL.
+	nextIDToken string
+}
+
+// newTestIssuer starts an httptest.Server that serves a fake OIDC
+// discovery document, token endpoint and JWKS, and returns the
+// issuer's nextIDToken from its token endpoint whenever it is called.
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate RSA key: %v", err)
+	}
+	iss := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc(wellKnownPath, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(providerConfig{
+			AuthorizationEndpoint: iss.URL + "/authorize",
+			TokenEndpoint:         iss.URL + "/token",
+			JWKSURI:               iss.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwkSet{
+			Keys: []jwk{{
+				Kid: "test-key",
+				Kty: "RSA",
+				N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big64(key.PublicKey.E)),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokenResponse{IDToken: iss.nextIDToken})
+	})
+	iss.Server = httptest.NewServer(mux)
+	return iss
+}
+
+// big64 encodes a uint exponent as the minimal big-endian byte slice
+// used in a JWK's "e" member.
+func big64(e int) []byte {
+	b := []byte{byte(e >> 16), byte(e >> 8), byte(e)}
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return b
+}
+
+// signToken builds a compact-serialized JWT with the given claims,
+// signed with iss's key.
+func signToken(t *testing.T, iss *testIssuer, claims map[string]interface{}) string {
+	header, err := json.Marshal(jwtHeader{Alg: "RS256", Kid: "test-key"})
+	if err != nil {
+		t.Fatalf("cannot marshal header: %v", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("cannot marshal claims: %v", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatalf("cannot sign token: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims(p *Params) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                p.Issuer,
+		"aud":                p.ClientID,
+		"sub":                "user1",
+		"preferred_username": "user1",
+		"exp":                float64(time.Now().Add(time.Hour).Unix()),
+	}
+}
+
+func testParams(issuer string) *Params {
+	return &Params{
+		Name:     "test",
+		Issuer:   issuer,
+		ClientID: "client-id",
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	iss := newTestIssuer(t)
+	defer iss.Close()
+	p := testParams(iss.URL)
+	cfg, err := p.discover()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.JWKSURI != iss.URL+"/jwks" {
+		t.Fatalf("unexpected jwks_uri: %q", cfg.JWKSURI)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	iss := newTestIssuer(t)
+	defer iss.Close()
+	p := testParams(iss.URL)
+	iss.nextIDToken = signToken(t, iss, validClaims(p))
+	username, _, err := p.Exchange("code", "https://candid.example.com/callback", &codeVerifier{Verifier: "verifier"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "user1" {
+		t.Fatalf("unexpected username: %q", username)
+	}
+}
+
+func TestExchangeTamperedSignature(t *testing.T) {
+	iss := newTestIssuer(t)
+	defer iss.Close()
+	p := testParams(iss.URL)
+	token := signToken(t, iss, validClaims(p))
+	// Flip the last byte of the signature so it no longer verifies.
+	iss.nextIDToken = token[:len(token)-1] + flip(token[len(token)-1])
+	if _, _, err := p.Exchange("code", "https://candid.example.com/callback", &codeVerifier{Verifier: "verifier"}); err == nil {
+		t.Fatal("expected an error for a tampered signature")
+	}
+}
+
+func TestExchangeExpiredToken(t *testing.T) {
+	iss := newTestIssuer(t)
+	defer iss.Close()
+	p := testParams(iss.URL)
+	claims := validClaims(p)
+	claims["exp"] = float64(time.Now().Add(-time.Hour).Unix())
+	iss.nextIDToken = signToken(t, iss, claims)
+	if _, _, err := p.Exchange("code", "https://candid.example.com/callback", &codeVerifier{Verifier: "verifier"}); err == nil {
+		t.Fatal("expected an error for an expired token")
+	}
+}
+
+func TestExchangeWrongAudience(t *testing.T) {
+	iss := newTestIssuer(t)
+	defer iss.Close()
+	p := testParams(iss.URL)
+	claims := validClaims(p)
+	claims["aud"] = "some-other-client"
+	iss.nextIDToken = signToken(t, iss, claims)
+	if _, _, err := p.Exchange("code", "https://candid.example.com/callback", &codeVerifier{Verifier: "verifier"}); err == nil {
+		t.Fatal("expected an error for an ID token issued for a different audience")
+	}
+}
+
+// flip returns a single-character string whose byte differs from c,
+// for use in corrupting a base64url-encoded signature. The last
+// character of an unpadded 256-byte signature carries only two data
+// bits (its low four bits are padding, ignored when decoding), so the
+// replacement differs from c in those top two bits.
+func flip(c byte) string {
+	if c == 'A' {
+		return "Q"
+	}
+	return "A"
+}
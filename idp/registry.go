@@ -0,0 +1,31 @@
+// Copyright 2015 Canonical Ltd.
+
+package idp
+
+import "gopkg.in/errgo.v1"
+
+// Factory unmarshals the configuration for an identity provider of a
+// particular type, made accessible through unmarshal, and returns the
+// constructed IdentityProvider. Factory implementations are expected
+// to behave in the way described for yaml.Unmarshaler.
+type Factory func(unmarshal func(interface{}) error) (IdentityProvider, error)
+
+// factories holds the set of identity provider factories registered
+// with Register, keyed by identity provider type.
+var factories = make(map[string]Factory)
+
+// Register registers factory as the means of constructing identity
+// providers of the given typeName. Register will typically be called
+// from the init function of a package implementing an identity
+// provider, so that the provider becomes available for use in
+// configuration simply by importing that package for its side
+// effects. This allows identity providers to be added, including by
+// packages outside candid itself, without modifying idp.go.
+//
+// Register panics if typeName has already been registered.
+func Register(typeName string, factory Factory) {
+	if _, ok := factories[typeName]; ok {
+		panic(errgo.Newf("identity provider type %q already registered", typeName))
+	}
+	factories[typeName] = factory
+}
@@ -0,0 +1,56 @@
+// Copyright 2015 Canonical Ltd.
+
+// Package usso provides the "usso" identity provider, which
+// authenticates users against Ubuntu SSO.
+package usso
+
+import (
+	"net/http"
+
+	"gopkg.in/errgo.v1"
+
+	"github.com/CanonicalLtd/candid/idp"
+)
+
+// Type is the identity provider type name used in configuration to
+// select this identity provider.
+const Type = "usso"
+
+// openIDEndpoint is the Ubuntu SSO OpenID endpoint used to
+// authenticate users, and to check the health of the service.
+const openIDEndpoint = "https://login.ubuntu.com/+openid"
+
+// config implements idp.HealthChecker for the usso identity provider,
+// which otherwise takes no configuration.
+type config struct{}
+
+// HealthCheck implements idp.HealthChecker by checking that the
+// Ubuntu SSO OpenID endpoint is reachable.
+func (config) HealthCheck() error {
+	resp, err := http.Get(openIDEndpoint)
+	if err != nil {
+		return errgo.Notef(err, "cannot reach Ubuntu SSO")
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errgo.Newf("Ubuntu SSO returned unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+// IdentityProvider is an identity provider that uses Ubuntu SSO.
+var IdentityProvider = idp.IdentityProvider{
+	Type:   Type,
+	Config: config{},
+}
+
+func init() {
+	idp.Register(Type, unmarshal)
+}
+
+// unmarshal implements idp.Factory. The usso identity provider takes
+// no configuration beyond its type, so unmarshal simply returns
+// IdentityProvider.
+func unmarshal(unmarshal func(interface{}) error) (idp.IdentityProvider, error) {
+	return IdentityProvider, nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2015 Canonical Ltd.
+
+// Package ussooauth provides the "usso_oauth" identity provider, which
+// authenticates users against Ubuntu SSO using OAuth.
+package ussooauth
+
+import "github.com/CanonicalLtd/candid/idp"
+
+// Type is the identity provider type name used in configuration to
+// select this identity provider.
+const Type = "usso_oauth"
+
+// IdentityProvider is an identity provider that uses Ubuntu SSO OAuth.
+var IdentityProvider = idp.IdentityProvider{
+	Type: Type,
+}
+
+func init() {
+	idp.Register(Type, unmarshal)
+}
+
+// unmarshal implements idp.Factory. The usso_oauth identity provider
+// takes no configuration beyond its type, so unmarshal simply returns
+// IdentityProvider.
+func unmarshal(unmarshal func(interface{}) error) (idp.IdentityProvider, error) {
+	return IdentityProvider, nil
+}
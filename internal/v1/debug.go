@@ -3,9 +3,13 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/juju/utils/debugstatus"
+
+	"github.com/CanonicalLtd/candid/idp"
 )
 
 // GET /debug
@@ -15,9 +19,68 @@ func (h *Handler) serveDebug(http.ResponseWriter, *http.Request) error {
 
 // GET /debug/status
 func (h *Handler) serveDebugStatus(_ http.Header, req *http.Request) (interface{}, error) {
-	return debugstatus.Check(
+	checks := []debugstatus.CheckerFunc{
 		debugstatus.StartTime,
-		debugstatus.Connection(h.store.DB.Session),
-		debugstatus.MongoCollections(h.store.DB),
-	), nil
-}
\ No newline at end of file
+		timedMongoCheck("connection", debugstatus.Connection(h.store.DB.Session)),
+		timedMongoCheck("collections", debugstatus.MongoCollections(h.store.DB)),
+	}
+	checks = append(checks, identityProviderChecks(h.idps)...)
+	return debugstatus.Check(checks...), nil
+}
+
+// timedMongoCheck wraps check, a debugstatus check that performs the
+// MongoDB operation named by operation, so that its duration is
+// recorded with recordMongoOp in addition to being reported as
+// before.
+func timedMongoCheck(operation string, check debugstatus.CheckerFunc) debugstatus.CheckerFunc {
+	return func() (string, debugstatus.CheckResult) {
+		start := time.Now()
+		key, result := check()
+		recordMongoOp(operation, start)
+		return key, result
+	}
+}
+
+// identityProviderChecks returns a debugstatus check for every
+// identity provider in idps whose Config implements
+// idp.HealthChecker, so that pluggable identity providers registered
+// with idp.Register automatically contribute a health check.
+func identityProviderChecks(idps []idp.IdentityProvider) []debugstatus.CheckerFunc {
+	var checks []debugstatus.CheckerFunc
+	for _, p := range idps {
+		hc, ok := p.Config.(idp.HealthChecker)
+		if !ok {
+			continue
+		}
+		checks = append(checks, identityProviderCheck(p, hc))
+	}
+	return checks
+}
+
+// identityProviderCheck returns a debugstatus check that reports
+// whether the identity provider p is reachable, using hc to perform
+// the check. When p.Config also implements idp.Namer, the check is
+// keyed and labelled by both Type and name, so that multiple
+// configured instances of the same Type do not collide.
+func identityProviderCheck(p idp.IdentityProvider, hc idp.HealthChecker) debugstatus.CheckerFunc {
+	return func() (string, debugstatus.CheckResult) {
+		key := "identity-provider-" + p.Type
+		name := fmt.Sprintf("Identity provider %q", p.Type)
+		if n, ok := p.Config.(idp.Namer); ok {
+			key += "-" + n.IdentityProviderName()
+			name = fmt.Sprintf("Identity provider %q (%s)", p.Type, n.IdentityProviderName())
+		}
+		if err := hc.HealthCheck(); err != nil {
+			return key, debugstatus.CheckResult{
+				Name:   name,
+				Value:  err.Error(),
+				Passed: false,
+			}
+		}
+		return key, debugstatus.CheckResult{
+			Name:   name,
+			Value:  "connected",
+			Passed: true,
+		}
+	}
+}